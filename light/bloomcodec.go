@@ -0,0 +1,112 @@
+// Copyright 2020 The go-blockchain Authors
+// This file is part of the go-blockchain library.
+//
+// The go-blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-blockchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-blockchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package light
+
+import (
+	"fmt"
+
+	"github.com/blockchain/go-blockchain/common"
+	"github.com/blockchain/go-blockchain/common/bitutil"
+)
+
+// BloomCodec compresses and decompresses a single bloombits vector for storage
+// as a BloomTrie leaf. Every codec is identified by a one-byte Tag, which is
+// stored, prefixed with bloomTrieValueMagic, as the first two bytes of the
+// trie value so the format can evolve (e.g. operators trading CPU for
+// storage/bandwidth on low-entropy sections) without a hard fork of the
+// helper-trie layout.
+type BloomCodec interface {
+	Tag() byte
+	Compress(src []byte) []byte
+	Decompress(src []byte, targetLen int) ([]byte, error)
+}
+
+// bloomTrieValueMagic is prepended, together with a codec tag, to every
+// BloomTrie leaf written since codec tagging was introduced. A single tag
+// byte alone is not enough to tell a tagged leaf apart from a legacy one
+// written before tagging existed: bitutil.CompressBytes stores a vector that
+// does not compress verbatim, and such a vector often starts with 0x00, the
+// same byte as sparseBloomCodecTag, so a legacy leaf could decode successfully, but
+// wrongly, through the tagged path. Pairing the tag with this magic byte
+// makes that collision astronomically unlikely instead of routine; see
+// EncodeBloomTrieValue and DecodeBloomTrieValue in odr_util.go.
+const bloomTrieValueMagic = 0xb7
+
+// EncodeBloomTrieValue compresses src with codec and prefixes the result with
+// bloomTrieValueMagic and codec's tag, producing the value BloomTrieIndexerBackend.Commit
+// stores for a BloomTrie leaf.
+func EncodeBloomTrieValue(codec BloomCodec, src []byte) []byte {
+	return append([]byte{bloomTrieValueMagic, codec.Tag()}, codec.Compress(src)...)
+}
+
+// bloomCodecs is the registry of codecs known to this node, keyed by tag.
+var bloomCodecs = make(map[byte]BloomCodec)
+
+// RegisterBloomCodec adds codec to the registry under its own Tag. It panics
+// on a tag collision, since that would silently make one of the two codecs
+// unreachable.
+func RegisterBloomCodec(codec BloomCodec) {
+	if _, exists := bloomCodecs[codec.Tag()]; exists {
+		panic(fmt.Sprintf("bloom codec tag 0x%02x already registered", codec.Tag()))
+	}
+	bloomCodecs[codec.Tag()] = codec
+}
+
+func init() {
+	RegisterBloomCodec(DefaultBloomCodec)
+	RegisterBloomCodec(rawBloomCodec{})
+}
+
+const sparseBloomCodecTag = 0x00
+
+// DefaultBloomCodec is the original codec: bitutil's sparse-bit run-length
+// compression. It is also what a legacy trie value predating codec tagging is
+// assumed to be, see DecodeBloomTrieValue in odr_util.go.
+var DefaultBloomCodec BloomCodec = sparseBloomCodec{}
+
+type sparseBloomCodec struct{}
+
+func (sparseBloomCodec) Tag() byte { return sparseBloomCodecTag }
+
+func (sparseBloomCodec) Compress(src []byte) []byte {
+	return bitutil.CompressBytes(src)
+}
+
+func (sparseBloomCodec) Decompress(src []byte, targetLen int) ([]byte, error) {
+	return bitutil.DecompressBytes(src, targetLen)
+}
+
+const rawBloomCodecTag = 0x01
+
+// rawBloomCodec stores the bloombits vector verbatim, tagged 0x01. It trades
+// space for CPU on low-entropy sections, where the runs bitutil's sparse codec
+// looks for are too short to pay for its per-run overhead and the "compressed"
+// output ends up larger than the input.
+type rawBloomCodec struct{}
+
+func (rawBloomCodec) Tag() byte { return rawBloomCodecTag }
+
+func (rawBloomCodec) Compress(src []byte) []byte {
+	return common.CopyBytes(src)
+}
+
+func (rawBloomCodec) Decompress(src []byte, targetLen int) ([]byte, error) {
+	if len(src) != targetLen {
+		return nil, fmt.Errorf("invalid raw bloom vector length: have %d, want %d", len(src), targetLen)
+	}
+	return common.CopyBytes(src), nil
+}
@@ -0,0 +1,73 @@
+// Copyright 2020 The go-blockchain Authors
+// This file is part of the go-blockchain library.
+//
+// The go-blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-blockchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-blockchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package light
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/blockchain/go-blockchain/common/bitutil"
+)
+
+// TestDecodeBloomTrieValueTagged checks that a leaf written via
+// EncodeBloomTrieValue round-trips through DecodeBloomTrieValue for both
+// registered codecs.
+func TestDecodeBloomTrieValueTagged(t *testing.T) {
+	const targetLen = 32
+	src := make([]byte, targetLen)
+	src[0], src[17] = 0xff, 0x42
+
+	for _, codec := range []BloomCodec{DefaultBloomCodec, rawBloomCodec{}} {
+		enc := EncodeBloomTrieValue(codec, src)
+		dec, err := DecodeBloomTrieValue(enc, targetLen)
+		if err != nil {
+			t.Fatalf("codec tag 0x%02x: failed to decode: %v", codec.Tag(), err)
+		}
+		if !bytes.Equal(dec, src) {
+			t.Fatalf("codec tag 0x%02x: got %x, want %x", codec.Tag(), dec, src)
+		}
+	}
+}
+
+// TestDecodeBloomTrieValueLegacyFallback checks that a pre-tagging leaf -
+// the raw output of bitutil.CompressBytes, with no bloomTrieValueMagic/tag
+// prefix - whose first byte happens to equal sparseBloomCodecTag is not
+// routed through the tagged codec path but decoded whole as legacy data.
+// Before bloomTrieValueMagic was introduced, such a leaf could silently
+// decode one byte short via sparseBloomCodec instead of falling back here.
+func TestDecodeBloomTrieValueLegacyFallback(t *testing.T) {
+	const targetLen = 32
+	// A dense vector does not compress, so CompressBytes stores it verbatim,
+	// and its first byte is 0x00.
+	src := make([]byte, targetLen)
+	for i := range src {
+		src[i] = byte(i)
+	}
+
+	legacy := bitutil.CompressBytes(src)
+	if len(legacy) == 0 || legacy[0] != sparseBloomCodecTag {
+		t.Fatalf("test fixture must reproduce the 0x00 leading byte this test guards against, got %x", legacy)
+	}
+
+	dec, err := DecodeBloomTrieValue(legacy, targetLen)
+	if err != nil {
+		t.Fatalf("failed to decode legacy value: %v", err)
+	}
+	if !bytes.Equal(dec, src) {
+		t.Fatalf("legacy fallback decode mismatch: got %x, want %x", dec, src)
+	}
+}
@@ -0,0 +1,188 @@
+// Copyright 2019 The go-blockchain Authors
+// This file is part of the go-blockchain library.
+//
+// The go-blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-blockchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-blockchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package light
+
+import (
+	"encoding/binary"
+	"errors"
+	"sync"
+
+	"github.com/blockchain/go-blockchain/common"
+	"github.com/blockchain/go-blockchain/crypto"
+	"github.com/blockchain/go-blockchain/ethdb"
+	"github.com/blockchain/go-blockchain/log"
+	"github.com/blockchain/go-blockchain/rlp"
+)
+
+// checkpointPrefix + sectionIdx (uint64 big endian) -> RLP encoded TrustedCheckpoint
+var checkpointPrefix = []byte("checkpoint-")
+
+// errCheckpointNotTrusted is returned by CheckpointOracle.Verify when an
+// announcement does not carry enough valid signatures from trusted signers.
+var errCheckpointNotTrusted = errors.New("checkpoint announcement lacks enough trusted signatures")
+
+// errThresholdNotConfigured is returned by CheckpointOracle.Verify when
+// SetThreshold has not yet been called with a positive value. Without it,
+// checking len(seen) against the zero-value threshold would accept an
+// announcement carrying no valid trusted signatures at all.
+var errThresholdNotConfigured = errors.New("checkpoint oracle signature threshold not configured")
+
+// CheckpointAnnounce is the LES announcement-style message a server sends to
+// propose a new trusted checkpoint: the CHT/BloomTrie roots for a section,
+// the section head, and the signatures of the servers vouching for it.
+type CheckpointAnnounce struct {
+	SectionIdx  uint64
+	SectionHead common.Hash
+	CHTRoot     common.Hash
+	BloomRoot   common.Hash
+	Signatures  [][]byte
+}
+
+// sigHash is the message each signature in a CheckpointAnnounce is expected
+// to cover: keccak256(sectionIdx || sectionHead || chtRoot || bloomTrieRoot).
+func (a *CheckpointAnnounce) sigHash() common.Hash {
+	var encIdx [8]byte
+	binary.BigEndian.PutUint64(encIdx[:], a.SectionIdx)
+	return crypto.Keccak256Hash(encIdx[:], a.SectionHead.Bytes(), a.CHTRoot.Bytes(), a.BloomRoot.Bytes())
+}
+
+// CheckpointOracle verifies LES announcement-style signed checkpoints against a
+// configurable set of trusted server keys and persists the ones that collect
+// enough signatures. It lets a mobile/embedded client bootstrap from (and skip
+// Ethash header verification below) a checkpoint it never configured itself,
+// as long as enough of the servers it already trusts vouch for it.
+type CheckpointOracle struct {
+	lock      sync.RWMutex
+	db        ethdb.Database
+	signers   map[common.Address]struct{}
+	threshold int
+}
+
+// NewCheckpointOracle creates a checkpoint oracle backed by db. It trusts no
+// signers and has no threshold, so Verify rejects every announcement until
+// configured via AddTrustedSigner and SetThreshold.
+func NewCheckpointOracle(db ethdb.Database) *CheckpointOracle {
+	return &CheckpointOracle{
+		db:      db,
+		signers: make(map[common.Address]struct{}),
+	}
+}
+
+// AddTrustedSigner registers the given secp256k1 public key as a signer whose
+// signature counts towards the threshold required by Verify.
+func (o *CheckpointOracle) AddTrustedSigner(pubkey []byte) error {
+	pub, err := crypto.UnmarshalPubkey(pubkey)
+	if err != nil {
+		return err
+	}
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	o.signers[crypto.PubkeyToAddress(*pub)] = struct{}{}
+	return nil
+}
+
+// SetThreshold sets the minimum number of distinct trusted signatures an
+// announcement must carry before Verify accepts it.
+func (o *CheckpointOracle) SetThreshold(n int) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	o.threshold = n
+}
+
+// Verify recovers the signer of every signature in ann, discards the ones
+// that don't recover to a trusted signer or don't match the announcement's
+// own roots, deduplicates the remaining signers and requires at least the
+// configured threshold of them before trusting the checkpoint it describes.
+func (o *CheckpointOracle) Verify(ann *CheckpointAnnounce) (TrustedCheckpoint, error) {
+	o.lock.RLock()
+	defer o.lock.RUnlock()
+
+	if o.threshold <= 0 {
+		return TrustedCheckpoint{}, errThresholdNotConfigured
+	}
+
+	hash := ann.sigHash()
+	seen := make(map[common.Address]struct{})
+	for _, sig := range ann.Signatures {
+		pub, err := crypto.Ecrecover(hash.Bytes(), sig)
+		if err != nil {
+			continue // an invalid signature does not disqualify the others
+		}
+		addr := common.BytesToAddress(crypto.Keccak256(pub[1:])[12:])
+		if _, trusted := o.signers[addr]; !trusted {
+			continue
+		}
+		seen[addr] = struct{}{}
+	}
+	if len(seen) < o.threshold {
+		return TrustedCheckpoint{}, errCheckpointNotTrusted
+	}
+	return TrustedCheckpoint{
+		SectionIdx:  ann.SectionIdx,
+		SectionHead: ann.SectionHead,
+		CHTRoot:     ann.CHTRoot,
+		BloomRoot:   ann.BloomRoot,
+	}, nil
+}
+
+// RegisterCheckpoint verifies cp against sigs and, if it meets the configured
+// signer threshold, persists it under the checkpoint- prefix keyed by section
+// index and returns it. It is the hook a LightChain can call for an
+// announcement it receives, to install a checkpoint it never configured
+// itself and skip Ethash verification for headers below it.
+func (o *CheckpointOracle) RegisterCheckpoint(cp TrustedCheckpoint, sigs [][]byte) (TrustedCheckpoint, error) {
+	verified, err := o.Verify(&CheckpointAnnounce{
+		SectionIdx:  cp.SectionIdx,
+		SectionHead: cp.SectionHead,
+		CHTRoot:     cp.CHTRoot,
+		BloomRoot:   cp.BloomRoot,
+		Signatures:  sigs,
+	})
+	if err != nil {
+		return TrustedCheckpoint{}, err
+	}
+	o.storeCheckpoint(verified)
+	return verified, nil
+}
+
+// Checkpoint returns the previously registered checkpoint for the given
+// section, if any.
+func (o *CheckpointOracle) Checkpoint(sectionIdx uint64) (TrustedCheckpoint, bool) {
+	var encIdx [8]byte
+	binary.BigEndian.PutUint64(encIdx[:], sectionIdx)
+	data, _ := o.db.Get(append(checkpointPrefix, encIdx[:]...))
+	if len(data) == 0 {
+		return TrustedCheckpoint{}, false
+	}
+	var cp TrustedCheckpoint
+	if err := rlp.DecodeBytes(data, &cp); err != nil {
+		log.Error("Failed to decode trusted checkpoint", "section", sectionIdx, "err", err)
+		return TrustedCheckpoint{}, false
+	}
+	return cp, true
+}
+
+func (o *CheckpointOracle) storeCheckpoint(cp TrustedCheckpoint) {
+	var encIdx [8]byte
+	binary.BigEndian.PutUint64(encIdx[:], cp.SectionIdx)
+	enc, err := rlp.EncodeToBytes(cp)
+	if err != nil {
+		log.Error("Failed to encode trusted checkpoint", "err", err)
+		return
+	}
+	o.db.Put(append(checkpointPrefix, encIdx[:]...), enc)
+}
@@ -0,0 +1,85 @@
+// Copyright 2020 The go-blockchain Authors
+// This file is part of the go-blockchain library.
+//
+// The go-blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-blockchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-blockchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package light
+
+import (
+	"testing"
+
+	"github.com/blockchain/go-blockchain/common"
+	"github.com/blockchain/go-blockchain/crypto"
+	"github.com/blockchain/go-blockchain/ethdb/memorydb"
+)
+
+// TestCheckpointOracleRequiresThreshold checks that Verify rejects every
+// announcement, even one carrying valid trusted signatures, until
+// SetThreshold has configured a positive threshold.
+func TestCheckpointOracleRequiresThreshold(t *testing.T) {
+	oracle := NewCheckpointOracle(memorydb.New())
+
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	if err := oracle.AddTrustedSigner(crypto.FromECDSAPub(&key.PublicKey)); err != nil {
+		t.Fatalf("failed to add trusted signer: %v", err)
+	}
+
+	ann := &CheckpointAnnounce{SectionIdx: 1, SectionHead: common.Hash{1}}
+	hash := ann.sigHash()
+	sig, err := crypto.Sign(hash.Bytes(), key)
+	if err != nil {
+		t.Fatalf("failed to sign announcement: %v", err)
+	}
+	ann.Signatures = [][]byte{sig}
+
+	if _, err := oracle.Verify(ann); err != errThresholdNotConfigured {
+		t.Fatalf("expected errThresholdNotConfigured before SetThreshold, got %v", err)
+	}
+
+	oracle.SetThreshold(1)
+	if _, err := oracle.Verify(ann); err != nil {
+		t.Fatalf("expected a trusted, sufficiently signed announcement to verify, got %v", err)
+	}
+}
+
+// TestCheckpointOracleThreshold checks that Verify requires the configured
+// number of distinct trusted signatures, rejecting an announcement that
+// falls short even when every signature it does carry is valid.
+func TestCheckpointOracleThreshold(t *testing.T) {
+	oracle := NewCheckpointOracle(memorydb.New())
+	oracle.SetThreshold(2)
+
+	trusted, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	if err := oracle.AddTrustedSigner(crypto.FromECDSAPub(&trusted.PublicKey)); err != nil {
+		t.Fatalf("failed to add trusted signer: %v", err)
+	}
+
+	ann := &CheckpointAnnounce{SectionIdx: 1, SectionHead: common.Hash{1}}
+	hash := ann.sigHash()
+	sig, err := crypto.Sign(hash.Bytes(), trusted)
+	if err != nil {
+		t.Fatalf("failed to sign announcement: %v", err)
+	}
+	ann.Signatures = [][]byte{sig}
+
+	if _, err := oracle.Verify(ann); err != errCheckpointNotTrusted {
+		t.Fatalf("expected errCheckpointNotTrusted with only 1 of 2 required signatures, got %v", err)
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright 2017 The go-blockchain Authors
+// This file is part of the go-blockchain library.
+//
+// The go-blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-blockchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-blockchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package light
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/blockchain/go-blockchain/common"
+	"github.com/blockchain/go-blockchain/ethdb"
+)
+
+// ErrNotFound is returned by NodeSet.Get when the requested node is not part
+// of the set.
+var ErrNotFound = errors.New("not found")
+
+// NodeSet stores a set of trie nodes, keyed by hash. It is used to collect
+// and hand around the nodes referenced by a Merkle proof without depending
+// on a full database, e.g. the proof returned by an ODR retrieval or by
+// ChtIndexerBackend.Prove/BloomTrieIndexerBackend.Prove.
+type NodeSet struct {
+	lock     sync.RWMutex
+	nodes    map[string][]byte
+	dataSize int
+}
+
+// NewNodeSet creates an empty node set.
+func NewNodeSet() *NodeSet {
+	return &NodeSet{nodes: make(map[string][]byte)}
+}
+
+// Put stores a new node in the set. Re-adding an already stored key is a
+// no-op, so merging proofs that share interior nodes does not count them
+// twice.
+func (db *NodeSet) Put(key []byte, value []byte) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	if _, ok := db.nodes[string(key)]; ok {
+		return nil
+	}
+	keyCopy := common.CopyBytes(key)
+	valueCopy := common.CopyBytes(value)
+	db.nodes[string(keyCopy)] = valueCopy
+	db.dataSize += len(valueCopy)
+	return nil
+}
+
+// Get returns a stored node.
+func (db *NodeSet) Get(key []byte) ([]byte, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	if entry, ok := db.nodes[string(key)]; ok {
+		return entry, nil
+	}
+	return nil, ErrNotFound
+}
+
+// Has returns whether a node is present in the set.
+func (db *NodeSet) Has(key []byte) (bool, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	_, ok := db.nodes[string(key)]
+	return ok, nil
+}
+
+// KeyCount returns the number of nodes in the set.
+func (db *NodeSet) KeyCount() int {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	return len(db.nodes)
+}
+
+// DataSize returns the aggregated data size of nodes in the set.
+func (db *NodeSet) DataSize() int {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	return db.dataSize
+}
+
+// Store writes the contents of the set into the given database.
+func (db *NodeSet) Store(target ethdb.Putter) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	for key, value := range db.nodes {
+		target.Put([]byte(key), value)
+	}
+}
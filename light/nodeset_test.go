@@ -0,0 +1,94 @@
+// Copyright 2020 The go-blockchain Authors
+// This file is part of the go-blockchain library.
+//
+// The go-blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-blockchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-blockchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package light
+
+import (
+	"testing"
+
+	"github.com/blockchain/go-blockchain/common"
+	"github.com/blockchain/go-blockchain/ethdb/memorydb"
+	"github.com/blockchain/go-blockchain/trie"
+)
+
+// TestNodeSetProveVerify builds a small trie, proves several keys into a
+// single NodeSet the way ChtIndexerBackend.Prove/BloomTrieIndexerBackend.Prove
+// do, and checks that VerifyHelperTrieProofs recovers every value from that
+// one NodeSet without touching the original trie database.
+func TestNodeSetProveVerify(t *testing.T) {
+	triedb := trie.NewDatabase(memorydb.New())
+	tr, err := trie.New(common.Hash{}, triedb)
+	if err != nil {
+		t.Fatalf("failed to create trie: %v", err)
+	}
+	entries := map[string]string{
+		"aaaa": "value-a",
+		"bbbb": "value-b",
+		"cccc": "value-c",
+	}
+	for k, v := range entries {
+		tr.Update([]byte(k), []byte(v))
+	}
+	root, err := tr.Commit(nil)
+	if err != nil {
+		t.Fatalf("failed to commit trie: %v", err)
+	}
+
+	nodes := NewNodeSet()
+	var keys [][]byte
+	for k := range entries {
+		keys = append(keys, []byte(k))
+		if err := tr.Prove([]byte(k), 0, nodes); err != nil {
+			t.Fatalf("failed to prove key %q: %v", k, err)
+		}
+	}
+
+	values, err := VerifyHelperTrieProofs(root, keys, nodes)
+	if err != nil {
+		t.Fatalf("failed to verify proofs: %v", err)
+	}
+	for i, k := range keys {
+		if got, want := string(values[i]), entries[string(k)]; got != want {
+			t.Errorf("key %q: got value %q, want %q", k, got, want)
+		}
+	}
+}
+
+// TestNodeSetStore checks that Store writes every node in the set into the
+// target database, so a NodeSet fetched via ODR can be used to prime a local
+// trie database (see ChtIndexerBackend.fetchTail/BloomTrieIndexerBackend.fetchTail).
+func TestNodeSetStore(t *testing.T) {
+	nodes := NewNodeSet()
+	if err := nodes.Put([]byte("key1"), []byte("value1")); err != nil {
+		t.Fatalf("failed to put: %v", err)
+	}
+	if err := nodes.Put([]byte("key2"), []byte("value2")); err != nil {
+		t.Fatalf("failed to put: %v", err)
+	}
+
+	target := memorydb.New()
+	nodes.Store(target)
+
+	for _, kv := range [][2]string{{"key1", "value1"}, {"key2", "value2"}} {
+		got, err := target.Get([]byte(kv[0]))
+		if err != nil {
+			t.Fatalf("key %q missing from target after Store: %v", kv[0], err)
+		}
+		if string(got) != kv[1] {
+			t.Errorf("key %q: got %q, want %q", kv[0], got, kv[1])
+		}
+	}
+}
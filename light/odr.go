@@ -0,0 +1,104 @@
+// Copyright 2017 The go-blockchain Authors
+// This file is part of the go-blockchain library.
+//
+// The go-blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-blockchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-blockchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package light
+
+import (
+	"context"
+	"time"
+
+	"github.com/blockchain/go-blockchain/common"
+	"github.com/blockchain/go-blockchain/core"
+	"github.com/blockchain/go-blockchain/core/rawdb"
+	"github.com/blockchain/go-blockchain/ethdb"
+)
+
+// odrFetchTimeout bounds a single ODR round trip issued by the indexer
+// backends in postprocess.go while they are primed or fed in light client
+// mode.
+const odrFetchTimeout = time.Second * 10
+
+// OdrBackend is an interface to an on-demand retrieval mechanism that can
+// satisfy requests for state that a light client does not hold locally, by
+// fetching it from LES servers and verifying it against locally known roots.
+type OdrBackend interface {
+	Database() ethdb.Database
+	ChtIndexer() *core.ChainIndexer
+	BloomTrieIndexer() *core.ChainIndexer
+	BloomIndexer() *core.ChainIndexer
+	Retrieve(ctx context.Context, req OdrRequest) error
+}
+
+// OdrRequest is the interface implemented by every request type that can be
+// passed to OdrBackend.Retrieve. Once the backend has obtained and verified
+// the requested data it calls StoreResult so the caller's local database
+// reflects the fetched result.
+type OdrRequest interface {
+	StoreResult(db ethdb.Database)
+}
+
+// ChtRequest is an ODR request for a Merkle proof of a single (encoded block
+// number -> ChtNode) entry in a Canonical Hash Trie. It is used by
+// ChtIndexerBackend to prime a light client's local trie database with the
+// tail of a previous CHT section so indexing of the next section can resume
+// without holding the full historical trie.
+type ChtRequest struct {
+	ChtRoot  common.Hash
+	ChtNum   uint64
+	BlockNum uint64
+	Proof    *NodeSet // filled in by the network retrieval implementation
+}
+
+// StoreResult implements OdrRequest.
+func (r *ChtRequest) StoreResult(db ethdb.Database) {
+	if r.Proof != nil {
+		r.Proof.Store(db)
+	}
+}
+
+// BloomTrieTailRequest is an ODR request for a Merkle proof of a single
+// (encoded bit index -> compressed bloombits vector) entry in a BloomTrie.
+// It plays the same role for BloomTrieIndexerBackend that ChtRequest plays
+// for ChtIndexerBackend: priming a light client's local trie database with
+// the previous section's nodes, one request per bit index.
+type BloomTrieTailRequest struct {
+	BloomTrieRoot common.Hash
+	TrieNum       uint64
+	BitIdx        uint
+	Proof         *NodeSet // filled in by the network retrieval implementation
+}
+
+// StoreResult implements OdrRequest.
+func (r *BloomTrieTailRequest) StoreResult(db ethdb.Database) {
+	if r.Proof != nil {
+		r.Proof.Store(db)
+	}
+}
+
+// BloomRequest is an ODR request for a single compressed bloombits vector,
+// used by BloomTrieIndexerBackend to build a BloomTrie section on a light
+// client instead of reading it from the local bloombits database.
+type BloomRequest struct {
+	BitIdx      uint
+	Section     uint64
+	SectionHead common.Hash
+	Bits        []byte // filled in by the network retrieval implementation
+}
+
+// StoreResult implements OdrRequest.
+func (r *BloomRequest) StoreResult(db ethdb.Database) {
+	rawdb.WriteBloomBits(db, r.BitIdx, r.Section, r.SectionHead, r.Bits)
+}
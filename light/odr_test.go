@@ -0,0 +1,265 @@
+// Copyright 2020 The go-blockchain Authors
+// This file is part of the go-blockchain library.
+//
+// The go-blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-blockchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-blockchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package light
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/blockchain/go-blockchain/common"
+	"github.com/blockchain/go-blockchain/common/bitutil"
+	"github.com/blockchain/go-blockchain/core"
+	"github.com/blockchain/go-blockchain/core/rawdb"
+	"github.com/blockchain/go-blockchain/core/types"
+	"github.com/blockchain/go-blockchain/ethdb"
+	"github.com/blockchain/go-blockchain/ethdb/memorydb"
+	"github.com/blockchain/go-blockchain/trie"
+)
+
+// newTestHeader builds a minimal, distinct header for block num, chained off
+// parent, good enough to be indexed by ChtIndexerBackend/BloomTrieIndexerBackend.
+func newTestHeader(num uint64, parent common.Hash) *types.Header {
+	return &types.Header{
+		Number:     new(big.Int).SetUint64(num),
+		ParentHash: parent,
+		Extra:      binary.BigEndian.AppendUint64(nil, num),
+	}
+}
+
+// errUnexpectedRequest is returned by the fake OdrBackends below for any
+// request type they don't know how to answer.
+var errUnexpectedRequest = errors.New("fake OdrBackend: unexpected request type")
+
+// fakeOdrBackend is a minimal OdrBackend whose Retrieve is supplied by the
+// test. ChtIndexer/BloomTrieIndexer/BloomIndexer/Database are never called by
+// the code paths under test here.
+type fakeOdrBackend struct {
+	retrieve func(ctx context.Context, req OdrRequest) error
+}
+
+func (o *fakeOdrBackend) Database() ethdb.Database             { return nil }
+func (o *fakeOdrBackend) ChtIndexer() *core.ChainIndexer       { return nil }
+func (o *fakeOdrBackend) BloomTrieIndexer() *core.ChainIndexer { return nil }
+func (o *fakeOdrBackend) BloomIndexer() *core.ChainIndexer     { return nil }
+func (o *fakeOdrBackend) Retrieve(ctx context.Context, req OdrRequest) error {
+	return o.retrieve(ctx, req)
+}
+
+const testChtSectionSize = 128
+
+// writeChtSection drives backend through a full section of testChtSectionSize
+// headers, chained from parent, and commits it, returning the section head hash.
+func writeChtSection(t *testing.T, td ethdb.Database, backend *ChtIndexerBackend, section uint64, parent common.Hash) common.Hash {
+	t.Helper()
+	var head common.Hash
+	for i := uint64(0); i < testChtSectionSize; i++ {
+		num := section*testChtSectionSize + i
+		header := newTestHeader(num, parent)
+		hash := header.Hash()
+		rawdb.WriteTd(td, hash, num, big.NewInt(int64(num+1)))
+		backend.Process(header)
+		parent = hash
+		head = hash
+	}
+	if err := backend.Commit(); err != nil {
+		t.Fatalf("failed to commit CHT section %d: %v", section, err)
+	}
+	return head
+}
+
+// TestChtIndexerLightModeResume exercises the full light-client CHT resume
+// path fixed in "persist fetched ODR proof nodes before resuming CHT/
+// BloomTrie": a light backend that holds none of a section's trie nodes
+// locally primes itself via fetchTail's ODR proof request, then successfully
+// processes and commits the next section. Before that fix, the Reset call
+// below failed with a missing trie node error on every invocation, since
+// fetchTail fetched the proof but never stored it.
+func TestChtIndexerLightModeResume(t *testing.T) {
+	fullDiskdb := memorydb.New()
+	full := &ChtIndexerBackend{
+		diskdb:      fullDiskdb,
+		triedb:      trie.NewDatabase(ethdb.NewTable(fullDiskdb, ChtTablePrefix)),
+		sectionSize: testChtSectionSize,
+	}
+	if err := full.Reset(0, common.Hash{}); err != nil {
+		t.Fatalf("failed to reset full backend: %v", err)
+	}
+	writeChtSection(t, fullDiskdb, full, 0, common.Hash{})
+
+	// fakeOdr serves a ChtRequest by proving the requested key against the
+	// "full" node's own trie database, the way a real OdrBackend would after
+	// fetching and verifying the same proof from a network peer.
+	fakeOdr := &fakeOdrBackend{retrieve: func(ctx context.Context, req OdrRequest) error {
+		r, ok := req.(*ChtRequest)
+		if !ok {
+			return errUnexpectedRequest
+		}
+		tr, err := trie.New(r.ChtRoot, trie.NewDatabase(ethdb.NewTable(fullDiskdb, ChtTablePrefix)))
+		if err != nil {
+			return err
+		}
+		var encNumber [8]byte
+		binary.BigEndian.PutUint64(encNumber[:], r.BlockNum)
+		nodes := NewNodeSet()
+		if err := tr.Prove(encNumber[:], 0, nodes); err != nil {
+			return err
+		}
+		r.Proof = nodes
+		return nil
+	}}
+
+	lightDiskdb := memorydb.New()
+	light := &ChtIndexerBackend{
+		diskdb:      lightDiskdb,
+		odr:         fakeOdr,
+		triedb:      trie.NewDatabase(ethdb.NewTable(lightDiskdb, ChtTablePrefix)),
+		sectionSize: testChtSectionSize,
+	}
+	// The light client already knows section 0's root/head, e.g. from a
+	// checkpoint, but (unlike the full node above) holds none of its trie
+	// nodes locally - that's the whole point of the ODR resume path.
+	StoreChtRoot(lightDiskdb, 0, full.lastHash, GetChtRoot(fullDiskdb, 0, full.lastHash))
+
+	if err := light.Reset(1, full.lastHash); err != nil {
+		t.Fatalf("light backend failed to resume section 1 via ODR: %v", err)
+	}
+	writeChtSection(t, lightDiskdb, light, 1, full.lastHash)
+
+	if root := GetChtRoot(lightDiskdb, 1, light.lastHash); root == (common.Hash{}) {
+		t.Fatalf("expected light backend to have committed a root for section 1")
+	}
+}
+
+const (
+	testParentSectionSize = 16
+	testBloomTrieRatio    = 2
+	testBloomSectionSize  = testParentSectionSize * testBloomTrieRatio
+)
+
+// writeParentBloomBits writes a distinct, compressed bloombits vector for
+// every bit of the given parent (bloombits) section into db, simulating a
+// full node's locally indexed bloombits database.
+func writeParentBloomBits(db ethdb.Database, parentSection uint64, head common.Hash) {
+	for bit := uint(0); bit < types.BloomBitLength; bit++ {
+		vector := make([]byte, testParentSectionSize/8)
+		vector[0], vector[len(vector)-1] = byte(parentSection), byte(bit)
+		rawdb.WriteBloomBits(db, bit, parentSection, head, bitutil.CompressBytes(vector))
+	}
+}
+
+// TestBloomTrieIndexerLightModeResume exercises the BloomTrie analogue of
+// TestChtIndexerLightModeResume: fetchTail's ODR proofs prime the light
+// backend's trie, and Commit's per-bit readBloomBits fetches every bloombits
+// vector via ODR (BloomRequest) instead of the local bloombits database,
+// since a light client never indexed the underlying bloombits sections
+// itself.
+func TestBloomTrieIndexerLightModeResume(t *testing.T) {
+	fullDiskdb := memorydb.New()
+	full := &BloomTrieIndexerBackend{
+		diskdb:            fullDiskdb,
+		triedb:            trie.NewDatabase(ethdb.NewTable(fullDiskdb, BloomTrieTablePrefix)),
+		codec:             DefaultBloomCodec,
+		sectionSize:       testBloomSectionSize,
+		parentSectionSize: testParentSectionSize,
+		bloomTrieRatio:    testBloomTrieRatio,
+		sectionHeads:      make([]common.Hash, testBloomTrieRatio),
+	}
+	if err := full.Reset(0, common.Hash{}); err != nil {
+		t.Fatalf("failed to reset full backend: %v", err)
+	}
+	// Populate sectionHeads directly instead of driving Process with real
+	// headers: Process's only job is filling in this slice at the right
+	// offsets (not exercised by this test), and what Commit/readBloomBits
+	// care about is that the bloombits data and the section head used to
+	// key it agree.
+	for j := uint64(0); j < testBloomTrieRatio; j++ {
+		head := common.Hash{byte(j + 1)}
+		writeParentBloomBits(fullDiskdb, j, head)
+		full.sectionHeads[j] = head
+	}
+	if err := full.Commit(); err != nil {
+		t.Fatalf("failed to commit full BloomTrie section 0: %v", err)
+	}
+	fullHead := full.sectionHeads[testBloomTrieRatio-1]
+
+	// fakeOdr answers both requests BloomTrieIndexerBackend issues in light
+	// mode: BloomTrieTailRequest (fetchTail, used to resume the trie) and
+	// BloomRequest (readBloomBits, used on every Commit).
+	fakeOdr := &fakeOdrBackend{retrieve: func(ctx context.Context, req OdrRequest) error {
+		switch r := req.(type) {
+		case *BloomTrieTailRequest:
+			tr, err := trie.New(r.BloomTrieRoot, trie.NewDatabase(ethdb.NewTable(fullDiskdb, BloomTrieTablePrefix)))
+			if err != nil {
+				return err
+			}
+			var encKey [10]byte
+			binary.BigEndian.PutUint16(encKey[0:2], uint16(r.BitIdx))
+			binary.BigEndian.PutUint64(encKey[2:10], r.TrieNum)
+			nodes := NewNodeSet()
+			if err := tr.Prove(encKey[:], 0, nodes); err != nil {
+				return err
+			}
+			r.Proof = nodes
+			return nil
+		case *BloomRequest:
+			data, err := rawdb.ReadBloomBits(fullDiskdb, r.BitIdx, r.Section, r.SectionHead)
+			if err != nil {
+				return err
+			}
+			r.Bits = data
+			return nil
+		default:
+			return errUnexpectedRequest
+		}
+	}}
+
+	lightDiskdb := memorydb.New()
+	light := &BloomTrieIndexerBackend{
+		diskdb:            lightDiskdb,
+		odr:               fakeOdr,
+		triedb:            trie.NewDatabase(ethdb.NewTable(lightDiskdb, BloomTrieTablePrefix)),
+		codec:             DefaultBloomCodec,
+		sectionSize:       testBloomSectionSize,
+		parentSectionSize: testParentSectionSize,
+		bloomTrieRatio:    testBloomTrieRatio,
+		sectionHeads:      make([]common.Hash, testBloomTrieRatio),
+	}
+	// As in the CHT case, the light client already knows section 0's root
+	// and head but holds none of its trie nodes locally.
+	StoreBloomTrieRoot(lightDiskdb, 0, fullHead, GetBloomTrieRoot(fullDiskdb, 0, fullHead))
+
+	if err := light.Reset(1, fullHead); err != nil {
+		t.Fatalf("light backend failed to resume section 1 via ODR: %v", err)
+	}
+	for j := uint64(0); j < testBloomTrieRatio; j++ {
+		parentSection := testBloomTrieRatio + j
+		head := common.Hash{byte(parentSection + 1)}
+		writeParentBloomBits(fullDiskdb, parentSection, head)
+		light.sectionHeads[j] = head
+	}
+	if err := light.Commit(); err != nil {
+		t.Fatalf("light backend failed to commit section 1 via ODR: %v", err)
+	}
+
+	lightHead := light.sectionHeads[testBloomTrieRatio-1]
+	if root := GetBloomTrieRoot(lightDiskdb, 1, lightHead); root == (common.Hash{}) {
+		t.Fatalf("expected light backend to have committed a root for section 1")
+	}
+}
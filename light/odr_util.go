@@ -0,0 +1,47 @@
+// Copyright 2017 The go-blockchain Authors
+// This file is part of the go-blockchain library.
+//
+// The go-blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-blockchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-blockchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package light
+
+import (
+	"github.com/blockchain/go-blockchain/common/bitutil"
+)
+
+// DecodeBloomTrieValue decompresses the raw value stored for a BloomTrie leaf
+// back into a full-length bloombits vector. targetLen is the length in bytes
+// of the vector it was compressed from (the section size in blocks divided
+// by 8). Callers that read a
+// BloomTrie leaf via an ODR proof (rather than building one, see
+// BloomTrieIndexerBackend.Commit) go through this helper so the decoding side
+// of the format lives in one place.
+//
+// enc is only dispatched against the BloomCodec registry when it starts with
+// the bloomTrieValueMagic/tag pair EncodeBloomTrieValue prefixes new leaves
+// with, and the decoded result is only trusted if it also comes out exactly
+// targetLen bytes long. A leaf written before codec tagging was introduced
+// carries neither, so it falls through and is decompressed whole using the
+// original sparse codec; a single tag byte alone could not tell the two
+// apart; see bloomTrieValueMagic in bloomcodec.go.
+func DecodeBloomTrieValue(enc []byte, targetLen int) ([]byte, error) {
+	if len(enc) > 1 && enc[0] == bloomTrieValueMagic {
+		if codec, ok := bloomCodecs[enc[1]]; ok {
+			if dec, err := codec.Decompress(enc[2:], targetLen); err == nil && len(dec) == targetLen {
+				return dec, nil
+			}
+		}
+	}
+	return bitutil.DecompressBytes(enc, targetLen)
+}
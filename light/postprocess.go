@@ -17,6 +17,8 @@
 package light
 
 import (
+	"bytes"
+	"context"
 	"encoding/binary"
 	"errors"
 	"math/big"
@@ -34,48 +36,93 @@ import (
 	"github.com/blockchain/go-blockchain/trie"
 )
 
-const (
-	// CHTFrequencyClient is the block frequency for creating CHTs on the client side.
-	CHTFrequencyClient = 32768
-
-	// CHTFrequencyServer is the block frequency for creating CHTs on the server side.
-	// Eventually this can be merged back with the client version, but that requires a
-	// full database upgrade, so that should be left for a suitable moment.
-	CHTFrequencyServer = 4096
+// IndexerConfig configures the section size and confirmation depth of a
+// core.ChainIndexer-based backend below. It replaces the old clientMode bool
+// switch so tests and alternative chains can configure indexers without
+// touching this package.
+type IndexerConfig struct {
+	SectionSize uint64
+	ConfirmReq  uint64
+
+	// BloomCodec selects the compression codec used for BloomTrie leaves.
+	// It is only meaningful for the config passed to NewBloomTrieIndexer; a
+	// nil value selects DefaultBloomCodec.
+	BloomCodec BloomCodec
+}
 
-	HelperTrieConfirmations        = 2048 // number of confirmations before a server is expected to have the given HelperTrie available
-	HelperTrieProcessConfirmations = 256  // number of confirmations before a HelperTrie is generated
+var (
+	// DefaultServerIndexerConfig is the indexer config a full node serving LES uses
+	// to build its CHT.
+	DefaultServerIndexerConfig = &IndexerConfig{SectionSize: params.CHTFrequency, ConfirmReq: params.HelperTrieProcessConfirmations}
+	// DefaultClientIndexerConfig is the indexer config a light client syncing via
+	// ODR uses to build its CHT.
+	DefaultClientIndexerConfig = &IndexerConfig{SectionSize: params.CHTFrequency, ConfirmReq: params.HelperTrieConfirmations}
+
+	// DefaultServerBloomIndexerConfig is the parent bloombits indexer config a
+	// full node uses; BloomTrie sections are an aggregate of several of these.
+	DefaultServerBloomIndexerConfig = &IndexerConfig{SectionSize: params.BloomBitsBlocks, ConfirmReq: params.BloomConfirms}
+	// DefaultClientBloomIndexerConfig is the parent bloombits indexer config a
+	// light client uses; it retrieves whole BloomTrie sections via ODR, so there
+	// is no sub-aggregation and the parent section equals the BloomTrie one.
+	DefaultClientBloomIndexerConfig = &IndexerConfig{SectionSize: params.BloomTrieFrequency, ConfirmReq: params.HelperTrieConfirmations}
+
+	// DefaultServerBloomTrieIndexerConfig is the BloomTrie indexer config a full
+	// node uses.
+	DefaultServerBloomTrieIndexerConfig = &IndexerConfig{SectionSize: params.BloomTrieFrequency, ConfirmReq: params.HelperTrieProcessConfirmations - params.BloomConfirms}
+	// DefaultClientBloomTrieIndexerConfig is the BloomTrie indexer config a light
+	// client uses.
+	DefaultClientBloomTrieIndexerConfig = &IndexerConfig{SectionSize: params.BloomTrieFrequency, ConfirmReq: params.HelperTrieConfirmations - params.BloomConfirms}
 )
 
-// trustedCheckpoint represents a set of post-processed trie roots (CHT and BloomTrie) associated with
+// TrustedCheckpoint represents a set of post-processed trie roots (CHT and BloomTrie) associated with
 // the appropriate section index and head hash. It is used to start light syncing from this checkpoint
 // and avoid downloading the entire header chain while still being able to securely access old headers/logs.
-type trustedCheckpoint struct {
-	name                                string
-	sectionIdx                          uint64
-	sectionHead, chtRoot, bloomTrieRoot common.Hash
+//
+// Its JSON form allows a checkpoint to be supplied at runtime (e.g. via config file or flag) instead of
+// only the hard-coded ones below, which is what lets a light client bootstrap from any checkpoint a user
+// trusts.
+type TrustedCheckpoint struct {
+	Name        string      `json:"-"`
+	SectionIdx  uint64      `json:"sectionIdx"`
+	SectionHead common.Hash `json:"sectionHead"`
+	CHTRoot     common.Hash `json:"chtRoot"`
+	BloomRoot   common.Hash `json:"bloomRoot"`
+}
+
+// HashEqual returns an indicator comparing the given hash with the section head.
+// For an empty checkpoint it reports whether the given hash is the zero hash.
+func (c *TrustedCheckpoint) HashEqual(hash common.Hash) bool {
+	if c.Empty() {
+		return hash == common.Hash{}
+	}
+	return c.SectionHead == hash
+}
+
+// Empty returns an indicator whether the checkpoint is regarded as empty.
+func (c *TrustedCheckpoint) Empty() bool {
+	return c.SectionHead == (common.Hash{}) || c.CHTRoot == (common.Hash{}) || c.BloomRoot == (common.Hash{})
 }
 
 var (
-	mainnetCheckpoint = trustedCheckpoint{
-		name:          "mainnet",
-		sectionIdx:    174,
-		sectionHead:   common.HexToHash("a3ef48cd8f1c3a08419f0237fc7763491fe89497b3144b17adf87c1c43664613"),
-		chtRoot:       common.HexToHash("dcbeed9f4dea1b3cb75601bb27c51b9960c28e5850275402ac49a150a667296e"),
-		bloomTrieRoot: common.HexToHash("6b7497a4a03e33870a2383cb6f5e70570f12b1bf5699063baf8c71d02ca90b02"),
+	mainnetCheckpoint = TrustedCheckpoint{
+		Name:        "mainnet",
+		SectionIdx:  174,
+		SectionHead: common.HexToHash("a3ef48cd8f1c3a08419f0237fc7763491fe89497b3144b17adf87c1c43664613"),
+		CHTRoot:     common.HexToHash("dcbeed9f4dea1b3cb75601bb27c51b9960c28e5850275402ac49a150a667296e"),
+		BloomRoot:   common.HexToHash("6b7497a4a03e33870a2383cb6f5e70570f12b1bf5699063baf8c71d02ca90b02"),
 	}
 
-	ropstenCheckpoint = trustedCheckpoint{
-		name:          "ropsten",
-		sectionIdx:    102,
-		sectionHead:   common.HexToHash("9017ab08465cb2b2dee035ee5b817bbd7fa28e2c8d2cd903e0aed1cccb249e89"),
-		chtRoot:       common.HexToHash("f61c10a7a787a5ef15f0ae1ae6c13c64331e57e79d0466d2bd9b0c06833fe956"),
-		bloomTrieRoot: common.HexToHash("69f2ad19aa46d5213a90137b3d2c9bff8a7c9483f7170f0125096ff450c9a873"),
+	ropstenCheckpoint = TrustedCheckpoint{
+		Name:        "ropsten",
+		SectionIdx:  102,
+		SectionHead: common.HexToHash("9017ab08465cb2b2dee035ee5b817bbd7fa28e2c8d2cd903e0aed1cccb249e89"),
+		CHTRoot:     common.HexToHash("f61c10a7a787a5ef15f0ae1ae6c13c64331e57e79d0466d2bd9b0c06833fe956"),
+		BloomRoot:   common.HexToHash("69f2ad19aa46d5213a90137b3d2c9bff8a7c9483f7170f0125096ff450c9a873"),
 	}
 )
 
 // trustedCheckpoints associates each known checkpoint with the genesis hash of the chain it belongs to
-var trustedCheckpoints = map[common.Hash]trustedCheckpoint{
+var trustedCheckpoints = map[common.Hash]TrustedCheckpoint{
 	params.MainnetGenesisHash: mainnetCheckpoint,
 	params.TestnetGenesisHash: ropstenCheckpoint,
 }
@@ -103,46 +150,71 @@ func GetChtRoot(db ethdb.Database, sectionIdx uint64, sectionHead common.Hash) c
 	return common.BytesToHash(data)
 }
 
-// GetChtV2Root reads the CHT root assoctiated to the given section from the database
-// Note that sectionIdx is specified according to LES/2 CHT section size
-func GetChtV2Root(db ethdb.Database, sectionIdx uint64, sectionHead common.Hash) common.Hash {
-	return GetChtRoot(db, (sectionIdx+1)*(CHTFrequencyClient/CHTFrequencyServer)-1, sectionHead)
-}
-
 // StoreChtRoot writes the CHT root assoctiated to the given section into the database
-// Note that sectionIdx is specified according to LES/1 CHT section size
 func StoreChtRoot(db ethdb.Database, sectionIdx uint64, sectionHead, root common.Hash) {
 	var encNumber [8]byte
 	binary.BigEndian.PutUint64(encNumber[:], sectionIdx)
 	db.Put(append(append(chtPrefix, encNumber[:]...), sectionHead.Bytes()...), root.Bytes())
 }
 
+// chtIndexerVersion is bumped whenever the on-disk CHT section size changes, so
+// upgradeChtIndexer can tell apart a database that already uses params.CHTFrequency
+// from one left over by a version that produced 4096-block (LES/1) sections.
+const chtIndexerVersion = 2
+
+var chtIndexerVersionKey = []byte("chtIndexerVersion")
+
+// upgradeChtIndexer drops the CHT root pointers and chain indexer progress left
+// behind by an older node that built 4096-block sections, so this node rebuilds
+// cleanly under the unified params.CHTFrequency instead of mixing section sizes.
+// The underlying trie nodes are left in place; they are simply never read again.
+func upgradeChtIndexer(db ethdb.Database) {
+	data, _ := db.Get(chtIndexerVersionKey)
+	if len(data) == 1 && data[0] == chtIndexerVersion {
+		return
+	}
+	log.Warn("Upgrading CHT index for the new section size", "oldFrequency", 4096, "newFrequency", params.CHTFrequency)
+	deletePrefix(db, chtPrefix)
+	deletePrefix(db, []byte("chtIndex-"))
+	db.Put(chtIndexerVersionKey, []byte{chtIndexerVersion})
+}
+
+// deletePrefix removes every key in db that starts with prefix.
+func deletePrefix(db ethdb.Database, prefix []byte) {
+	it := db.NewIterator()
+	defer it.Release()
+	for it.Next() {
+		if bytes.HasPrefix(it.Key(), prefix) {
+			db.Delete(it.Key())
+		}
+	}
+}
+
 // ChtIndexerBackend implements core.ChainIndexerBackend
 type ChtIndexerBackend struct {
 	diskdb               ethdb.Database
+	odr                  OdrBackend // non-nil puts the backend in light client mode
 	triedb               *trie.Database
 	section, sectionSize uint64
 	lastHash             common.Hash
 	trie                 *trie.Trie
 }
 
-// NewBloomTrieIndexer creates a BloomTrie chain indexer
-func NewChtIndexer(db ethdb.Database, clientMode bool) *core.ChainIndexer {
-	var sectionSize, confirmReq uint64
-	if clientMode {
-		sectionSize = CHTFrequencyClient
-		confirmReq = HelperTrieConfirmations
-	} else {
-		sectionSize = CHTFrequencyServer
-		confirmReq = HelperTrieProcessConfirmations
-	}
+// NewChtIndexer creates a Cht chain indexer. If odr is non-nil, the indexer runs in
+// light client mode: instead of expecting the previous section's trie nodes in the
+// local database, Reset fetches the ones it needs to resume a section via an ODR
+// Merkle proof request. Process reads each header's TD from db in both modes.
+func NewChtIndexer(db ethdb.Database, odr OdrBackend, config *IndexerConfig) *core.ChainIndexer {
+	upgradeChtIndexer(db)
+
 	idb := ethdb.NewTable(db, "chtIndex-")
 	backend := &ChtIndexerBackend{
 		diskdb:      db,
+		odr:         odr,
 		triedb:      trie.NewDatabase(ethdb.NewTable(db, ChtTablePrefix)),
-		sectionSize: sectionSize,
+		sectionSize: config.SectionSize,
 	}
-	return core.NewChainIndexer(db, idb, backend, sectionSize, confirmReq, time.Millisecond*100, "cht")
+	return core.NewChainIndexer(db, idb, backend, config.SectionSize, config.ConfirmReq, time.Millisecond*100, "cht")
 }
 
 // Reset implements core.ChainIndexerBackend
@@ -150,6 +222,11 @@ func (c *ChtIndexerBackend) Reset(section uint64, lastSectionHead common.Hash) e
 	var root common.Hash
 	if section > 0 {
 		root = GetChtRoot(c.diskdb, section-1, lastSectionHead)
+		if c.odr != nil {
+			if err := c.fetchTail(root, section-1); err != nil {
+				return err
+			}
+		}
 	}
 	var err error
 	c.trie, err = trie.New(root, c.triedb)
@@ -157,6 +234,23 @@ func (c *ChtIndexerBackend) Reset(section uint64, lastSectionHead common.Hash) e
 	return err
 }
 
+// fetchTail retrieves, via ODR, a Merkle proof for the last key of the given
+// section and stores the returned trie nodes in the local trie database. This
+// is what lets a light client - which never processed that section itself and
+// so holds none of its trie nodes - resume the trie rooted at root.
+func (c *ChtIndexerBackend) fetchTail(root common.Hash, section uint64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), odrFetchTimeout)
+	defer cancel()
+	r := &ChtRequest{ChtRoot: root, ChtNum: section, BlockNum: (section+1)*c.sectionSize - 1}
+	if err := c.odr.Retrieve(ctx, r); err != nil {
+		return err
+	}
+	if r.Proof != nil {
+		r.Proof.Store(ethdb.NewTable(c.diskdb, ChtTablePrefix))
+	}
+	return nil
+}
+
 // Process implements core.ChainIndexerBackend
 func (c *ChtIndexerBackend) Process(header *types.Header) {
 	hash, num := header.Hash(), header.Number.Uint64()
@@ -180,18 +274,54 @@ func (c *ChtIndexerBackend) Commit() error {
 	}
 	c.triedb.Commit(root, false)
 
-	if ((c.section+1)*c.sectionSize)%CHTFrequencyClient == 0 {
-		log.Info("Storing CHT", "section", c.section*c.sectionSize/CHTFrequencyClient, "head", c.lastHash, "root", root)
-	}
+	log.Info("Storing CHT", "section", c.section, "head", c.lastHash, "root", root)
 	StoreChtRoot(c.diskdb, c.section, c.lastHash, root)
 	return nil
 }
 
-const (
-	BloomTrieFrequency        = 32768
-	ethBloomBitsSection       = 4096
-	ethBloomBitsConfirmations = 256
-)
+// Prove generates a single, deduplicated Merkle proof covering all of the
+// given keys (encoded block numbers) against the CHT rooted at section/
+// sectionHead. Proving several keys into one NodeSet instead of one proof
+// per key means a server answering a batched GetHelperTrieProofsMsg sends
+// each interior node only once.
+//
+// section and sectionHead identify the requested section explicitly rather
+// than reusing the backend's own c.section/c.lastHash: those fields track
+// whatever section core.ChainIndexer is currently processing, are mutated by
+// its goroutine without synchronization, and in general are not the section
+// a peer is asking to be proven at all.
+func (c *ChtIndexerBackend) Prove(section uint64, sectionHead common.Hash, keys [][]byte) (*NodeSet, error) {
+	t, err := trie.New(GetChtRoot(c.diskdb, section, sectionHead), c.triedb)
+	if err != nil {
+		return nil, err
+	}
+	nodes := NewNodeSet()
+	for _, key := range keys {
+		if err := t.Prove(key, 0, nodes); err != nil {
+			return nil, err
+		}
+	}
+	return nodes, nil
+}
+
+// VerifyHelperTrieProofs verifies a Merkle proof for every key in keys against
+// root, using only the nodes contained in proof, and returns the raw leaf
+// value for each key in the same order. It underlies verification of both CHT
+// (ChtNode-valued) and BloomTrie (compressed bloombits-valued) proofs, since
+// both are just encoded values stored at an encoded-number key. The values are
+// returned as stored: ChtNodes are not RLP-decoded and bloombits vectors are
+// not decompressed (see DecodeBloomTrieValue).
+func VerifyHelperTrieProofs(root common.Hash, keys [][]byte, proof *NodeSet) ([][]byte, error) {
+	values := make([][]byte, len(keys))
+	for i, key := range keys {
+		value, _, err := trie.VerifyProof(root, key, proof)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+	return values, nil
+}
 
 var (
 	bloomTriePrefix      = []byte("bltRoot-") // bloomTriePrefix + bloomTrieNum (uint64 big endian) -> trie root hash
@@ -215,32 +345,38 @@ func StoreBloomTrieRoot(db ethdb.Database, sectionIdx uint64, sectionHead, root
 
 // BloomTrieIndexerBackend implements core.ChainIndexerBackend
 type BloomTrieIndexerBackend struct {
-	diskdb                                     ethdb.Database
-	triedb                                     *trie.Database
-	section, parentSectionSize, bloomTrieRatio uint64
-	trie                                       *trie.Trie
-	sectionHeads                               []common.Hash
+	diskdb                                                  ethdb.Database
+	odr                                                     OdrBackend // non-nil puts the backend in light client mode
+	triedb                                                  *trie.Database
+	codec                                                   BloomCodec
+	section, sectionSize, parentSectionSize, bloomTrieRatio uint64
+	trie                                                    *trie.Trie
+	sectionHeads                                            []common.Hash
 }
 
-// NewBloomTrieIndexer creates a BloomTrie chain indexer
-func NewBloomTrieIndexer(db ethdb.Database, clientMode bool) *core.ChainIndexer {
+// NewBloomTrieIndexer creates a BloomTrie chain indexer. If odr is non-nil, the indexer
+// runs in light client mode: Reset primes the trie via ODR proofs of the previous
+// section's entries, and Commit fetches each bloombits vector via ODR instead of reading
+// it out of the local bloombits database. parentConfig describes the underlying
+// bloombits indexer whose sections are aggregated into each BloomTrie section.
+func NewBloomTrieIndexer(db ethdb.Database, odr OdrBackend, parentConfig, config *IndexerConfig) *core.ChainIndexer {
+	codec := config.BloomCodec
+	if codec == nil {
+		codec = DefaultBloomCodec
+	}
 	backend := &BloomTrieIndexerBackend{
-		diskdb: db,
-		triedb: trie.NewDatabase(ethdb.NewTable(db, BloomTrieTablePrefix)),
+		diskdb:            db,
+		odr:               odr,
+		triedb:            trie.NewDatabase(ethdb.NewTable(db, BloomTrieTablePrefix)),
+		codec:             codec,
+		sectionSize:       config.SectionSize,
+		parentSectionSize: parentConfig.SectionSize,
 	}
 	idb := ethdb.NewTable(db, "bltIndex-")
 
-	var confirmReq uint64
-	if clientMode {
-		backend.parentSectionSize = BloomTrieFrequency
-		confirmReq = HelperTrieConfirmations
-	} else {
-		backend.parentSectionSize = ethBloomBitsSection
-		confirmReq = HelperTrieProcessConfirmations
-	}
-	backend.bloomTrieRatio = BloomTrieFrequency / backend.parentSectionSize
+	backend.bloomTrieRatio = config.SectionSize / backend.parentSectionSize
 	backend.sectionHeads = make([]common.Hash, backend.bloomTrieRatio)
-	return core.NewChainIndexer(db, idb, backend, BloomTrieFrequency, confirmReq-ethBloomBitsConfirmations, time.Millisecond*100, "bloomtrie")
+	return core.NewChainIndexer(db, idb, backend, config.SectionSize, config.ConfirmReq, time.Millisecond*100, "bloomtrie")
 }
 
 // Reset implements core.ChainIndexerBackend
@@ -248,6 +384,11 @@ func (b *BloomTrieIndexerBackend) Reset(section uint64, lastSectionHead common.H
 	var root common.Hash
 	if section > 0 {
 		root = GetBloomTrieRoot(b.diskdb, section-1, lastSectionHead)
+		if b.odr != nil {
+			if err := b.fetchTail(root, section-1); err != nil {
+				return err
+			}
+		}
 	}
 	var err error
 	b.trie, err = trie.New(root, b.triedb)
@@ -255,9 +396,30 @@ func (b *BloomTrieIndexerBackend) Reset(section uint64, lastSectionHead common.H
 	return err
 }
 
+// fetchTail retrieves, via ODR, a Merkle proof for every bit's key of the given
+// section and stores the returned trie nodes in the local trie database, so a
+// light client can resume the BloomTrie rooted at root without holding its
+// historical nodes locally. Keys are ordered by bit index first, so the next
+// section's key for every bit lies beside that bit's key in this section.
+func (b *BloomTrieIndexerBackend) fetchTail(root common.Hash, section uint64) error {
+	for bit := uint(0); bit < types.BloomBitLength; bit++ {
+		ctx, cancel := context.WithTimeout(context.Background(), odrFetchTimeout)
+		r := &BloomTrieTailRequest{BloomTrieRoot: root, TrieNum: section, BitIdx: bit}
+		err := b.odr.Retrieve(ctx, r)
+		cancel()
+		if err != nil {
+			return err
+		}
+		if r.Proof != nil {
+			r.Proof.Store(ethdb.NewTable(b.diskdb, BloomTrieTablePrefix))
+		}
+	}
+	return nil
+}
+
 // Process implements core.ChainIndexerBackend
 func (b *BloomTrieIndexerBackend) Process(header *types.Header) {
-	num := header.Number.Uint64() - b.section*BloomTrieFrequency
+	num := header.Number.Uint64() - b.section*b.sectionSize
 	if (num+1)%b.parentSectionSize == 0 {
 		b.sectionHeads[num/b.parentSectionSize] = header.Hash()
 	}
@@ -273,7 +435,7 @@ func (b *BloomTrieIndexerBackend) Commit() error {
 		binary.BigEndian.PutUint64(encKey[2:10], b.section)
 		var decomp []byte
 		for j := uint64(0); j < b.bloomTrieRatio; j++ {
-			data, err := rawdb.ReadBloomBits(b.diskdb, i, b.section*b.bloomTrieRatio+j, b.sectionHeads[j])
+			data, err := b.readBloomBits(i, b.section*b.bloomTrieRatio+j, b.sectionHeads[j])
 			if err != nil {
 				return err
 			}
@@ -283,12 +445,12 @@ func (b *BloomTrieIndexerBackend) Commit() error {
 			}
 			decomp = append(decomp, decompData...)
 		}
-		comp := bitutil.CompressBytes(decomp)
+		comp := b.codec.Compress(decomp)
 
 		decompSize += uint64(len(decomp))
 		compSize += uint64(len(comp))
 		if len(comp) > 0 {
-			b.trie.Update(encKey[:], comp)
+			b.trie.Update(encKey[:], append([]byte{bloomTrieValueMagic, b.codec.Tag()}, comp...))
 		} else {
 			b.trie.Delete(encKey[:])
 		}
@@ -304,4 +466,46 @@ func (b *BloomTrieIndexerBackend) Commit() error {
 	StoreBloomTrieRoot(b.diskdb, b.section, sectionHead, root)
 
 	return nil
-}
\ No newline at end of file
+}
+
+// readBloomBits returns a single compressed bloombits vector, either from the
+// local bloombits database (server/full-sync mode) or, when the backend is
+// running in light client mode, via an ODR request to a server that has
+// already indexed the corresponding section.
+func (b *BloomTrieIndexerBackend) readBloomBits(bit uint, section uint64, head common.Hash) ([]byte, error) {
+	if b.odr == nil {
+		return rawdb.ReadBloomBits(b.diskdb, bit, section, head)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), odrFetchTimeout)
+	defer cancel()
+	r := &BloomRequest{BitIdx: bit, Section: section, SectionHead: head}
+	if err := b.odr.Retrieve(ctx, r); err != nil {
+		return nil, err
+	}
+	return r.Bits, nil
+}
+
+// Prove generates a single, deduplicated Merkle proof covering all of the
+// given keys (10-byte bit-index||section encodings, see Commit) against the
+// BloomTrie rooted at section/sectionHead. As with ChtIndexerBackend.Prove,
+// batching many keys into one NodeSet lets a server answering adjacent
+// bloom-bit lookups in the same section send each interior node only once.
+//
+// section and sectionHead are taken as explicit parameters, not read from
+// b.section/b.sectionHeads, for the same reason as ChtIndexerBackend.Prove:
+// those fields belong to the indexer's own in-flight state and are mutated
+// by its goroutine while Prove may be called concurrently to serve an
+// arbitrary, often much older, section.
+func (b *BloomTrieIndexerBackend) Prove(section uint64, sectionHead common.Hash, keys [][]byte) (*NodeSet, error) {
+	t, err := trie.New(GetBloomTrieRoot(b.diskdb, section, sectionHead), b.triedb)
+	if err != nil {
+		return nil, err
+	}
+	nodes := NewNodeSet()
+	for _, key := range keys {
+		if err := t.Prove(key, 0, nodes); err != nil {
+			return nil, err
+		}
+	}
+	return nodes, nil
+}
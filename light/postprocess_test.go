@@ -0,0 +1,68 @@
+// Copyright 2020 The go-blockchain Authors
+// This file is part of the go-blockchain library.
+//
+// The go-blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-blockchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-blockchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package light
+
+import (
+	"testing"
+
+	"github.com/blockchain/go-blockchain/common"
+	"github.com/blockchain/go-blockchain/ethdb/memorydb"
+)
+
+// TestUpgradeChtIndexer checks that upgradeChtIndexer drops a stale,
+// pre-unification CHT section (and its chain indexer progress) exactly once:
+// it wipes leftover state the first time it runs against an un-versioned
+// database, then becomes a no-op once the version marker is in place.
+func TestUpgradeChtIndexer(t *testing.T) {
+	db := memorydb.New()
+
+	// Simulate state left behind by a node that indexed with the old,
+	// smaller CHT section size.
+	StoreChtRoot(db, 0, common.Hash{1}, common.Hash{2})
+	db.Put([]byte("chtIndex-stale"), []byte("stale"))
+
+	upgradeChtIndexer(db)
+
+	if root := GetChtRoot(db, 0, common.Hash{1}); root != (common.Hash{}) {
+		t.Fatalf("expected stale CHT root to be dropped, got %x", root)
+	}
+	if ok, _ := db.Has([]byte("chtIndex-stale")); ok {
+		t.Fatalf("expected stale chain indexer progress to be dropped")
+	}
+
+	// Once versioned, a second run must not touch newly written state.
+	StoreChtRoot(db, 0, common.Hash{1}, common.Hash{3})
+	upgradeChtIndexer(db)
+
+	if root := GetChtRoot(db, 0, common.Hash{1}); root != (common.Hash{3}) {
+		t.Fatalf("upgradeChtIndexer ran again after being versioned, got root %x", root)
+	}
+}
+
+// TestDefaultIndexerConfigSectionSizes checks that the client and server CHT/
+// BloomTrie indexer configs agree on section size, which is the invariant
+// IndexerConfig was introduced to guarantee: a light client's sections must
+// line up with a server's so a checkpoint/proof generated by one is
+// addressable by the other.
+func TestDefaultIndexerConfigSectionSizes(t *testing.T) {
+	if DefaultServerIndexerConfig.SectionSize != DefaultClientIndexerConfig.SectionSize {
+		t.Errorf("CHT section size mismatch: server %d, client %d", DefaultServerIndexerConfig.SectionSize, DefaultClientIndexerConfig.SectionSize)
+	}
+	if DefaultServerBloomTrieIndexerConfig.SectionSize != DefaultClientBloomTrieIndexerConfig.SectionSize {
+		t.Errorf("BloomTrie section size mismatch: server %d, client %d", DefaultServerBloomTrieIndexerConfig.SectionSize, DefaultClientBloomTrieIndexerConfig.SectionSize)
+	}
+}
@@ -0,0 +1,48 @@
+// Copyright 2019 The go-blockchain Authors
+// This file is part of the go-blockchain library.
+//
+// The go-blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-blockchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-blockchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package params
+
+// These are network parameters that need to be constant between clients, but
+// aren't necessarily consensus related. Notably the CHT, bloombits and helper
+// trie related constants live here so that both the light package (which
+// builds the tries) and the les package (which serves/requests them over the
+// wire) can share a single definition.
+const (
+	// CHTFrequency is the number of blocks a Canonical Hash Trie section covers.
+	// Both the client and the server build CHTs at this frequency; there used
+	// to be a separate, smaller CHTFrequencyServer, but that required an
+	// LES/1-only section-index translation that has since been removed.
+	CHTFrequency = 32768
+
+	// BloomBitsBlocks is the number of blocks a bloombits section covers.
+	BloomBitsBlocks uint64 = 4096
+
+	// BloomConfirms is the number of confirmation blocks before a bloombits
+	// section is considered final and its bits are rotated into storage.
+	BloomConfirms = 256
+
+	// BloomTrieFrequency is the number of blocks a BloomTrie section covers.
+	BloomTrieFrequency = 32768
+
+	// HelperTrieConfirmations is the number of confirmations before a server is
+	// expected to have the given HelperTrie (CHT or BloomTrie) available.
+	HelperTrieConfirmations = 2048
+
+	// HelperTrieProcessConfirmations is the number of confirmations before a
+	// HelperTrie (CHT or BloomTrie) section is generated.
+	HelperTrieProcessConfirmations = 256
+)